@@ -0,0 +1,209 @@
+package chain
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pchain/lightclient"
+)
+
+// APIs returns the collection of RPC services the chain manager offers, they are served by the main chain.
+func (cm *ChainManager) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   NewPrivateChainAdminAPI(cm),
+			Public:    false,
+		},
+		{
+			Namespace: "chain",
+			Version:   "1.0",
+			Service:   NewPublicChainHealthAPI(cm),
+			Public:    true,
+		},
+		{
+			Namespace: "chain",
+			Version:   "1.0",
+			Service:   NewPublicLightClientAPI(cm),
+			Public:    true,
+		},
+		{
+			Namespace: "chain",
+			Version:   "1.0",
+			Service:   NewPublicRelayerAPI(cm),
+			Public:    true,
+		},
+	}
+}
+
+// PrivateChainAdminAPI provides the operators the ability to take the child chains offline and bring them back at runtime.
+type PrivateChainAdminAPI struct {
+	cm *ChainManager
+}
+
+// NewPrivateChainAdminAPI creates a new API definition for the chain manager admin methods.
+func NewPrivateChainAdminAPI(cm *ChainManager) *PrivateChainAdminAPI {
+	return &PrivateChainAdminAPI{cm: cm}
+}
+
+// StopChildChain stops the child chain, detaches it from the P2P server and the RPC endpoints.
+func (api *PrivateChainAdminAPI) StopChildChain(chainId string) (bool, error) {
+	if err := api.cm.StopChildChain(chainId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// StartChildChain starts a stopped child chain again.
+func (api *PrivateChainAdminAPI) StartChildChain(chainId string) (bool, error) {
+	if err := api.cm.StartChildChain(chainId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ChildChains returns the ids of the child chains running on this node.
+func (api *PrivateChainAdminAPI) ChildChains() []string {
+	return api.cm.RunningChildChains()
+}
+
+// PublicChainHealthAPI reports the status of all the chains hosted by this node.
+type PublicChainHealthAPI struct {
+	cm *ChainManager
+}
+
+// NewPublicChainHealthAPI creates a new API definition for the chain health methods.
+func NewPublicChainHealthAPI(cm *ChainManager) *PublicChainHealthAPI {
+	return &PublicChainHealthAPI{cm: cm}
+}
+
+// GetChainsHealth returns the head, peers, sync progress and consensus status of each chain hosted by this node.
+func (api *PublicChainHealthAPI) GetChainsHealth() []*ChainHealth {
+	return api.cm.ChainsHealth()
+}
+
+// PublicLightClientAPI verifies the child chain data with the epochs kept by the main chain.
+type PublicLightClientAPI struct {
+	cm *ChainManager
+}
+
+// NewPublicLightClientAPI creates a new API definition for the child chain verification methods.
+func NewPublicLightClientAPI(cm *ChainManager) *PublicLightClientAPI {
+	return &PublicLightClientAPI{cm: cm}
+}
+
+// MerkleProofArgs is the merkle proof of the tx or receipt at the index of the block
+type MerkleProofArgs struct {
+	Index hexutil.Uint    `json:"index"`
+	Proof []hexutil.Bytes `json:"proof"` // the trie nodes from the root
+}
+
+// VerifiedChildChainHeader is the child chain header and the proven txs and receipts
+type VerifiedChildChainHeader struct {
+	ChainId  string               `json:"chainId"`
+	Number   hexutil.Uint64       `json:"number"`
+	Hash     common.Hash          `json:"hash"`
+	Txs      []*types.Transaction `json:"transactions"`
+	Receipts []*types.Receipt     `json:"receipts"`
+}
+
+// VerifyChildChainHeader verifies the child chain header is committed by the validators of its epoch,
+// and the optional merkle proofs of the txs and receipts against the header.
+func (api *PublicLightClientAPI) VerifyChildChainHeader(header *types.Header, txProofs, receiptProofs []MerkleProofArgs) (*VerifiedChildChainHeader, error) {
+	tdmExtra, err := lightclient.VerifyHeader(lightclient.NewChainInfoSource(api.cm.cch.chainInfoDB), header)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerifiedChildChainHeader{
+		ChainId:  tdmExtra.ChainID,
+		Number:   hexutil.Uint64(tdmExtra.Height),
+		Hash:     header.Hash(),
+		Txs:      make([]*types.Transaction, 0, len(txProofs)),
+		Receipts: make([]*types.Receipt, 0, len(receiptProofs)),
+	}
+
+	for _, p := range txProofs {
+		tx, err := lightclient.VerifyTx(header, uint(p.Index), proofFromArgs(p))
+		if err != nil {
+			return nil, err
+		}
+		result.Txs = append(result.Txs, tx)
+	}
+	for _, p := range receiptProofs {
+		receipt, err := lightclient.VerifyReceipt(header, uint(p.Index), proofFromArgs(p))
+		if err != nil {
+			return nil, err
+		}
+		result.Receipts = append(result.Receipts, receipt)
+	}
+	return result, nil
+}
+
+func proofFromArgs(args MerkleProofArgs) *types.BSKeyValueSet {
+	nodes := make([][]byte, len(args.Proof))
+	for i, node := range args.Proof {
+		nodes[i] = node
+	}
+	return lightclient.ProofFromNodes(nodes)
+}
+
+// PublicRelayerAPI reports the cross chain transfers completed by the relayer of this node.
+type PublicRelayerAPI struct {
+	cm *ChainManager
+}
+
+// NewPublicRelayerAPI creates a new API definition for the cross chain relayer methods.
+func NewPublicRelayerAPI(cm *ChainManager) *PublicRelayerAPI {
+	return &PublicRelayerAPI{cm: cm}
+}
+
+// TransferStatus is the progress of a cross chain transfer
+type TransferStatus struct {
+	TxHash       common.Hash    `json:"txHash"`
+	Kind         string         `json:"kind"` // deposit, withdraw or transfer
+	ChainId      string         `json:"chainId"`
+	From         common.Address `json:"from"`
+	Amount       *hexutil.Big   `json:"amount"`
+	Height       hexutil.Uint64 `json:"height"`
+	Status       string         `json:"status"` // pending, submitted, completed or failed
+	SecondTxHash *common.Hash   `json:"secondTxHash"`
+	Attempts     hexutil.Uint64 `json:"attempts"`
+	LastError    string         `json:"lastError"`
+}
+
+var (
+	transferKinds    = []string{"deposit", "withdraw", "transfer"}
+	transferStatuses = []string{"pending", "submitted", "completed", "failed"}
+)
+
+// GetTransferStatus returns the status of the transfer by the hash of its first leg, DepositInMainChain, WithdrawFromChildChain
+// or TransferToChildChain.
+func (api *PublicRelayerAPI) GetTransferStatus(txHash common.Hash) (*TransferStatus, error) {
+	if api.cm.relayer == nil {
+		return nil, ErrRelayerNotRunning
+	}
+
+	record := api.cm.relayer.GetTransfer(txHash)
+	if record == nil {
+		return nil, ErrTransferNotFound
+	}
+
+	result := &TransferStatus{
+		TxHash:    record.TxHash,
+		Kind:      transferKinds[record.Kind],
+		ChainId:   record.ChainId,
+		From:      record.From,
+		Amount:    (*hexutil.Big)(record.Amount),
+		Height:    hexutil.Uint64(record.Height),
+		Status:    transferStatuses[record.Status],
+		Attempts:  hexutil.Uint64(record.Attempts),
+		LastError: record.LastError,
+	}
+	if record.SecondTxHash != (common.Hash{}) {
+		result.SecondTxHash = &record.SecondTxHash
+	}
+	return result, nil
+}
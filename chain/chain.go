@@ -1,11 +1,13 @@
 package chain
 
 import (
+	"fmt"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	tdmTypes "github.com/ethereum/go-ethereum/consensus/pdbft/types"
 	"github.com/ethereum/go-ethereum/log"
 	eth "github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/pchain/ethereum"
 	"github.com/pchain/version"
 	cfg "github.com/tendermint/go-config"
@@ -81,7 +83,8 @@ func StartChain(ctx *cli.Context, chain *Chain, startDone chan<- struct{}) error
 	return nil
 }
 
-func CreateChildChain(ctx *cli.Context, chainId string, validator tdmTypes.PrivValidator, keyJson []byte, validators []tdmTypes.GenesisValidator) error {
+func CreateChildChain(ctx *cli.Context, chainId string, validator tdmTypes.PrivValidator, keyJson []byte, validators []tdmTypes.GenesisValidator,
+	forks *params.ChildForkSchedule) error {
 
 	// Get Tendermint config base on chain id
 	config := GetTendermintConfig(chainId, ctx)
@@ -96,13 +99,19 @@ func CreateChildChain(ctx *cli.Context, chainId string, validator tdmTypes.PrivV
 		}
 	}
 
-	// Save the Validator Json File
-	privValFile := config.GetString("priv_validator_file_root")
-	validator.SetFile(privValFile + ".json")
-	validator.Save()
+	// Save the Validator Json File, not needed if the key is held by the remote signer of the main chain
+	if validator.PrivKey == nil {
+		if GetCMInstance(ctx).mainChain.Config.GetString("priv_validator_laddr") == "" {
+			return fmt.Errorf("validator %x has neither the consensus key nor the remote signer", validator.Address)
+		}
+	} else {
+		privValFile := config.GetString("priv_validator_file_root")
+		validator.SetFile(privValFile + ".json")
+		validator.Save()
+	}
 
 	// Init the Ethereum Genesis
-	err := initEthGenesisFromExistValidator(chainId, config, validators)
+	err := initEthGenesisFromExistValidator(chainId, config, validators, forks)
 	if err != nil {
 		return err
 	}
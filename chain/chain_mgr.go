@@ -1,9 +1,11 @@
 package chain
 
 import (
+	"fmt"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/pdbft/epoch"
 	"github.com/ethereum/go-ethereum/consensus/pdbft/types"
@@ -23,6 +25,7 @@ import (
 	"net"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"sync"
 )
@@ -40,8 +43,9 @@ type ChainManager struct {
 
 	stop chan struct{} // Channel wait for PCHAIN stop
 
-	server *p2p.PChainP2PServer
-	cch    *CrossChainHelper
+	server  *p2p.PChainP2PServer
+	cch     *CrossChainHelper
+	relayer *Relayer
 }
 
 var chainMgr *ChainManager
@@ -83,6 +87,11 @@ func (cm *ChainManager) LoadMainChain(ctx *cli.Context) error {
 
 func (cm *ChainManager) LoadChains(childIds []string) error {
 
+	if _, err := getLightEthereumFromNode(cm.mainChain.EthNode); err == nil {
+		log.Info("Main Chain runs in light mode, Child Chains are not loaded")
+		return nil
+	}
+
 	childChainIds := core.GetChildChainIds(cm.cch.chainInfoDB)
 	log.Infof("Before Load Child Chains, childChainIds is %v, len is %d", childChainIds, len(childChainIds))
 
@@ -136,6 +145,7 @@ func (cm *ChainManager) InitCrossChainHelper() {
 		cm.mainChain.Config.GetString("db_backend"),
 		cm.ctx.GlobalString(utils.DataDirFlag.Name))
 	cm.cch.localTX3CacheDB, _ = rawdb.NewLevelDBDatabase(path.Join(cm.ctx.GlobalString(utils.DataDirFlag.Name), "tx3cache"), 0, 0, "pchain/db/tx3/")
+	cm.cch.outboxDB, _ = rawdb.NewLevelDBDatabase(path.Join(cm.ctx.GlobalString(utils.DataDirFlag.Name), "outbox"), 0, 0, "pchain/db/outbox/")
 
 	chainId := MainChain
 	if cm.ctx.GlobalBool(utils.TestnetFlag.Name) {
@@ -175,6 +185,9 @@ func (cm *ChainManager) StartMainChain() error {
 
 	cm.mainChain.EthNode.SetP2PServer(cm.server.Server())
 
+	// Chain Manager APIs are served by the Main Chain
+	cm.mainChain.EthNode.RegisterAPIs(cm.APIs())
+
 	if address, ok := cm.getNodeValidator(cm.mainChain.EthNode); ok {
 		cm.server.AddLocalValidator(cm.mainChain.Id, address)
 	}
@@ -192,68 +205,85 @@ func (cm *ChainManager) StartChains() error {
 
 	for _, chain := range cm.childChains {
 		// Start each Chain
-		srv := cm.server.Server()
-		childProtocols := chain.EthNode.GatherProtocols()
-		// Add Child Protocols to P2P Server Protocols
-		srv.Protocols = append(srv.Protocols, childProtocols...)
-		// Add Child Protocols to P2P Server Caps
-		srv.AddChildProtocolCaps(childProtocols)
+		if err := cm.startChildChain(chain); err != nil {
+			log.Errorf("Start Child Chain %s failed: %v", chain.Id, err)
+			return err
+		}
 
-		chain.EthNode.SetP2PServer(srv)
+		// Tell other peers that we have added into a new child chain
+		cm.server.BroadcastNewChildChainMsg(chain.Id)
+	}
 
-		if address, ok := cm.getNodeValidator(chain.EthNode); ok {
-			cm.server.AddLocalValidator(chain.Id, address)
-		}
+	return nil
+}
 
-		startDone := make(chan struct{})
-		StartChain(cm.ctx, chain, startDone)
-		<-startDone
+// startChildChain hooks up the child chain protocols to the shared P2P server and starts the chain
+func (cm *ChainManager) startChildChain(chain *Chain) error {
+	srv := cm.server.Server()
+	// Add Child Protocols to P2P Server Protocols and Caps
+	srv.AddChildProtocols(chain.EthNode.GatherProtocols())
 
-		cm.childQuits[chain.Id] = chain.EthNode.StopChan()
+	chain.EthNode.SetP2PServer(srv)
 
-		// Tell other peers that we have added into a new child chain
-		cm.server.BroadcastNewChildChainMsg(chain.Id)
+	if address, ok := cm.getNodeValidator(chain.EthNode); ok {
+		cm.server.AddLocalValidator(chain.Id, address)
+	}
+
+	// Start the Child Chain, and it will start child chain reactors as well
+	startDone := make(chan struct{})
+	err := StartChain(cm.ctx, chain, startDone)
+	<-startDone
+	if err != nil {
+		return err
 	}
 
+	cm.childQuits[chain.Id] = chain.EthNode.StopChan()
 	return nil
 }
 
+// hookupChainRPC serves the chain on the PChain RPC endpoints, with the API modules allowed for the chain
+func (cm *ChainManager) hookupChainRPC(chain *Chain) {
+	entry := chainRPCEntry(chain)
+	if rpc.IsHTTPRunning() {
+		if h, err := chain.EthNode.GetHTTPHandler(); err == nil {
+			rpc.HookupHTTP(entry, h)
+		} else {
+			log.Errorf("Unable Hook up Chain (%v) RPC HTTP Handler: %v", chain.Id, err)
+		}
+	}
+	if rpc.IsWSRunning() {
+		if h, err := chain.EthNode.GetWSHandler(); err == nil {
+			rpc.HookupWS(entry, h)
+		} else {
+			log.Errorf("Unable Hook up Chain (%v) RPC WS Handler: %v", chain.Id, err)
+		}
+	}
+}
+
+// chainRPCEntry describes the chain in the index of the RPC endpoints
+func chainRPCEntry(chain *Chain) rpc.ChainEntry {
+	entry := rpc.ChainEntry{ChainId: chain.Id}
+
+	var ethereum *eth.Ethereum
+	if err := chain.EthNode.Service(&ethereum); err == nil && ethereum != nil {
+		entry.NetworkId = ethereum.NetVersion()
+		entry.EthChainId = (*hexutil.Big)(ethereum.ChainConfig().ChainId)
+	}
+	return entry
+}
+
 func (cm *ChainManager) StartRPC() error {
 
-	// Start PChain RPC
+	// Start PChain RPC, the /health path reports the status of all the chains
+	rpc.SetHealthReporter(cm.Healthy)
 	err := rpc.StartRPC(cm.ctx)
 	if err != nil {
 		return err
-	} else {
-		if rpc.IsHTTPRunning() {
-			if h, err := cm.mainChain.EthNode.GetHTTPHandler(); err == nil {
-				rpc.HookupHTTP(cm.mainChain.Id, h)
-			} else {
-				log.Errorf("Load Main Chain RPC HTTP handler failed: %v", err)
-			}
-			for _, chain := range cm.childChains {
-				if h, err := chain.EthNode.GetHTTPHandler(); err == nil {
-					rpc.HookupHTTP(chain.Id, h)
-				} else {
-					log.Errorf("Load Child Chain RPC HTTP handler failed: %v", err)
-				}
-			}
-		}
+	}
 
-		if rpc.IsWSRunning() {
-			if h, err := cm.mainChain.EthNode.GetWSHandler(); err == nil {
-				rpc.HookupWS(cm.mainChain.Id, h)
-			} else {
-				log.Errorf("Load Main Chain RPC WS handler failed: %v", err)
-			}
-			for _, chain := range cm.childChains {
-				if h, err := chain.EthNode.GetWSHandler(); err == nil {
-					rpc.HookupWS(chain.Id, h)
-				} else {
-					log.Errorf("Load Child Chain RPC WS handler failed: %v", err)
-				}
-			}
-		}
+	cm.hookupChainRPC(cm.mainChain)
+	for _, chain := range cm.childChains {
+		cm.hookupChainRPC(chain)
 	}
 
 	return nil
@@ -261,11 +291,20 @@ func (cm *ChainManager) StartRPC() error {
 
 func (cm *ChainManager) StartInspectEvent() {
 
+	// The light Main Chain doesn't process the blocks, there is no Child Chain event
+	if _, err := getLightEthereumFromNode(cm.mainChain.EthNode); err == nil {
+		return
+	}
+
 	createChildChainCh := make(chan core.CreateChildChainEvent, 10)
 	createChildChainSub := MustGetEthereumFromNode(cm.mainChain.EthNode).BlockChain().SubscribeCreateChildChainEvent(createChildChainCh)
 
+	retireChildChainCh := make(chan core.RetireChildChainEvent, 10)
+	retireChildChainSub := MustGetEthereumFromNode(cm.mainChain.EthNode).BlockChain().SubscribeRetireChildChainEvent(retireChildChainCh)
+
 	go func() {
 		defer createChildChainSub.Unsubscribe()
+		defer retireChildChainSub.Unsubscribe()
 
 		for {
 			select {
@@ -278,8 +317,19 @@ func (cm *ChainManager) StartInspectEvent() {
 
 					cm.LoadChildChainInRT(event.ChainId)
 				}()
+			case event := <-retireChildChainCh:
+				log.Infof("RetireChildChainEvent received: %v", event)
+
+				// the main chain has settled the child chain, stop it if it's running here
+				go func() {
+					if err := cm.StopChildChain(event.ChainId); err != nil {
+						log.Debugf("RetireChildChainEvent: %v", err)
+					}
+				}()
 			case <-createChildChainSub.Err():
 				return
+			case <-retireChildChainSub.Err():
+				return
 			}
 		}
 	}()
@@ -353,9 +403,19 @@ func (cm *ChainManager) LoadChildChainInRT(chainId string) {
 
 	// child chain uses the same validator with the main chain.
 	privValidatorFile := cm.mainChain.Config.GetString("priv_validator_file")
-	self := types.LoadPrivValidator(privValidatorFile)
+	var self *types.PrivValidator
+	if laddr := cm.mainChain.Config.GetString("priv_validator_laddr"); laddr != "" {
+		var err error
+		self, err = types.LoadRemotePrivValidator(privValidatorFile, laddr, cm.mainChain.Config.GetString("priv_validator_secret_file"))
+		if err != nil {
+			log.Errorf("Create Child Chain %v failed! %v", chainId, err)
+			return
+		}
+	} else {
+		self = types.LoadPrivValidator(privValidatorFile)
+	}
 
-	err := CreateChildChain(cm.ctx, chainId, *self, keyJson, validators)
+	err := CreateChildChain(cm.ctx, chainId, *self, keyJson, validators, core.GetChildChainForks(cm.cch.chainInfoDB, chainId))
 	if err != nil {
 		log.Errorf("Create Child Chain %v failed! %v", chainId, err)
 		return
@@ -367,31 +427,11 @@ func (cm *ChainManager) LoadChildChainInRT(chainId string) {
 		return
 	}
 
-	//StartChildChain to attach p2p and rpc
-	//TODO Hookup new Created Child Chain to P2P server
-	srv := cm.server.Server()
-	childProtocols := chain.EthNode.GatherProtocols()
-	// Add Child Protocols to P2P Server Protocols
-	srv.Protocols = append(srv.Protocols, childProtocols...)
-	// Add Child Protocols to P2P Server Caps
-	srv.AddChildProtocolCaps(childProtocols)
-
-	chain.EthNode.SetP2PServer(srv)
-
-	if address, ok := cm.getNodeValidator(chain.EthNode); ok {
-		srv.AddLocalValidator(chain.Id, address)
-	}
-
-	// Start the new Child Chain, and it will start child chain reactors as well
-	startDone := make(chan struct{})
-	err = StartChain(cm.ctx, chain, startDone)
-	<-startDone
-	if err != nil {
+	// Hookup new Created Child Chain to P2P server and start it
+	if err := cm.startChildChain(chain); err != nil {
 		return
 	}
 
-	cm.childQuits[chain.Id] = chain.EthNode.StopChan()
-
 	var childEthereum *eth.Ethereum
 	chain.EthNode.Service(&childEthereum)
 	firstEpoch := childEthereum.Engine().(consensus.Tendermint).GetEpoch()
@@ -405,21 +445,89 @@ func (cm *ChainManager) LoadChildChainInRT(chainId string) {
 	go cm.server.BroadcastNewChildChainMsg(chainId)
 
 	//hookup rpc
-	if rpc.IsHTTPRunning() {
-		if h, err := chain.EthNode.GetHTTPHandler(); err == nil {
-			rpc.HookupHTTP(chain.Id, h)
-		} else {
-			log.Errorf("Unable Hook up Child Chain (%v) RPC HTTP Handler: %v", chainId, err)
-		}
+	cm.hookupChainRPC(chain)
+}
+
+// StopChildChain takes a running child chain offline, the main chain and the other child chains keep running
+func (cm *ChainManager) StopChildChain(chainId string) error {
+	cm.createChildChainLock.Lock()
+	defer cm.createChildChainLock.Unlock()
+
+	chain, ok := cm.childChains[chainId]
+	if !ok {
+		return fmt.Errorf("child chain %v is not running", chainId)
 	}
-	if rpc.IsWSRunning() {
-		if h, err := chain.EthNode.GetWSHandler(); err == nil {
-			rpc.HookupWS(chain.Id, h)
-		} else {
-			log.Errorf("Unable Hook up Child Chain (%v) RPC WS Handler: %v", chainId, err)
-		}
+
+	log.Infof("Stop Child Chain: %s", chainId)
+
+	// Unhook the rpc first, no more request goes into the child chain
+	rpc.UnhookHTTP(chainId)
+	rpc.UnhookWS(chainId)
+
+	// Detach the child chain from the P2P server and the connected peers
+	cm.server.Server().RemoveChildProtocols(chain.EthNode.GatherProtocols())
+	cm.server.DetachChildChain(chainId)
+
+	if address, ok := cm.getNodeValidator(chain.EthNode); ok {
+		cm.server.RemoveLocalValidator(chainId, address)
 	}
 
+	// Stop the child chain services, the shared P2P server keeps running
+	err := chain.EthNode.Close1()
+	if err != nil {
+		log.Error("Error when closing child chain", "child id", chainId, "err", err)
+	}
+
+	delete(cm.childChains, chainId)
+	delete(cm.childQuits, chainId)
+
+	log.Infof("Child Chain %s Stopped", chainId)
+	return err
+}
+
+// StartChildChain loads a stopped child chain and starts it again on the shared P2P server and RPC endpoints
+func (cm *ChainManager) StartChildChain(chainId string) error {
+	cm.createChildChainLock.Lock()
+	defer cm.createChildChainLock.Unlock()
+
+	if _, ok := cm.childChains[chainId]; ok {
+		return fmt.Errorf("child chain %v is already running", chainId)
+	}
+
+	if ci := core.GetChainInfo(cm.cch.chainInfoDB, chainId); ci == nil {
+		return fmt.Errorf("child chain %v does not exist", chainId)
+	}
+
+	chain := LoadChildChain(cm.ctx, chainId)
+	if chain == nil {
+		return fmt.Errorf("load child chain %v failed, make sure it has been initialized", chainId)
+	}
+
+	if err := cm.startChildChain(chain); err != nil {
+		return err
+	}
+	cm.childChains[chainId] = chain
+
+	// Tell other peers that we have added into the child chain again
+	go cm.server.BroadcastNewChildChainMsg(chainId)
+
+	cm.hookupChainRPC(chain)
+
+	log.Infof("Child Chain %s Started", chainId)
+	return nil
+}
+
+// RunningChildChains returns the ids of the child chains running on this node
+func (cm *ChainManager) RunningChildChains() []string {
+	cm.createChildChainLock.Lock()
+	defer cm.createChildChainLock.Unlock()
+
+	chainIds := make([]string, 0, len(cm.childChains))
+	for chainId := range cm.childChains {
+		chainIds = append(chainIds, chainId)
+	}
+	sort.Strings(chainIds)
+	return chainIds
 }
 
 func (cm *ChainManager) formalizeChildChain(chainId string, cci core.CoreChainInfo, ep *epoch.Epoch) {
@@ -442,6 +550,9 @@ func (cm *ChainManager) checkCoinbaseInChildChain(childEpoch *epoch.Epoch) bool
 }
 
 func (cm *ChainManager) StopChain() {
+	if cm.relayer != nil {
+		cm.relayer.Stop()
+	}
 	go func() {
 		mainChainError := cm.mainChain.EthNode.Close()
 		if mainChainError != nil {
@@ -450,19 +561,35 @@ func (cm *ChainManager) StopChain() {
 			log.Info("Main Chain Closed")
 		}
 	}()
+	// take the child chains under the lock, they are stopped and started at runtime
+	cm.createChildChainLock.Lock()
+	children := make([]*Chain, 0, len(cm.childChains))
 	for _, child := range cm.childChains {
-		go func() {
+		children = append(children, child)
+	}
+	cm.createChildChainLock.Unlock()
+
+	for _, child := range children {
+		go func(child *Chain) {
 			childChainError := child.EthNode.Close()
 			if childChainError != nil {
 				log.Error("Error when closing child chain", "child id", child.Id, "err", childChainError)
 			}
-		}()
+		}(child)
 	}
 }
 
 func (cm *ChainManager) WaitChainsStop() {
 	<-cm.mainQuit
+
+	cm.createChildChainLock.Lock()
+	quits := make([]<-chan struct{}, 0, len(cm.childQuits))
 	for _, quit := range cm.childQuits {
+		quits = append(quits, quit)
+	}
+	cm.createChildChainLock.Unlock()
+
+	for _, quit := range quits {
 		<-quit
 	}
 }
@@ -471,6 +598,7 @@ func (cm *ChainManager) Stop() {
 	rpc.StopRPC()
 	cm.server.Stop()
 	cm.cch.localTX3CacheDB.Close()
+	cm.cch.outboxDB.Close()
 	cm.cch.chainInfoDB.Close()
 
 	// Release the main routine
@@ -485,9 +613,12 @@ func (cm *ChainManager) getNodeValidator(ethNode *node.Node) (common.Address, bo
 
 	log.Debug("getNodeValidator")
 	var ethereum *eth.Ethereum
-	ethNode.Service(&ethereum)
-
 	var etherbase common.Address
+	if err := ethNode.Service(&ethereum); err != nil {
+		// No validator runs on the light node
+		return etherbase, false
+	}
+
 	if tdm, ok := ethereum.Engine().(consensus.Tendermint); ok {
 		epoch := ethereum.Engine().(consensus.Tendermint).GetEpoch()
 		etherbase = tdm.PrivateValidator()
@@ -500,7 +631,7 @@ func (cm *ChainManager) getNodeValidator(ethNode *node.Node) (common.Address, bo
 }
 
 func writeGenesisIntoChainInfoDB(db dbm.DB, childChainId string, validators []types.GenesisValidator) {
-	ethByte, _ := generateETHGenesis(childChainId, validators)
+	ethByte, _ := generateETHGenesis(childChainId, validators, core.GetChildChainForks(db, childChainId))
 	tdmByte, _ := generateTDMGenesis(childChainId, validators)
 	core.SaveChainGenesis(db, childChainId, ethByte, tdmByte)
 }
@@ -16,12 +16,14 @@ import (
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/les"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	pabi "github.com/pchain/abi"
+	"github.com/pchain/lightclient"
 	"github.com/tendermint/go-crypto"
 	dbm "github.com/tendermint/go-db"
 	"math/big"
@@ -36,6 +38,7 @@ type CrossChainHelper struct {
 	mtx             sync.Mutex
 	chainInfoDB     dbm.DB
 	localTX3CacheDB ethdb.Database
+	outboxDB        ethdb.Database
 	//the client does only connect to main chain
 	client      *ethclient.Client
 	mainChainId string
@@ -58,7 +61,7 @@ func (cch *CrossChainHelper) GetMainChainId() string {
 }
 
 // CanCreateChildChain check the condition before send the create child chain into the tx pool
-func (cch *CrossChainHelper) CanCreateChildChain(from common.Address, chainId string, minValidators uint16, minDepositAmount, startupCost *big.Int, startBlock, endBlock *big.Int) error {
+func (cch *CrossChainHelper) CanCreateChildChain(from common.Address, chainId string, minValidators uint16, minDepositAmount, startupCost *big.Int, startBlock, endBlock *big.Int, stateDB *state.StateDB) error {
 
 	if chainId == "" || strings.Contains(chainId, ";") {
 		return errors.New("chainId is nil or empty, or contains ';', should be meaningful")
@@ -90,19 +93,20 @@ func (cch *CrossChainHelper) CanCreateChildChain(from common.Address, chainId st
 	}
 
 	// Check the minimum validators
-	if minValidators < core.OFFICIAL_MINIMUM_VALIDATORS {
-		return fmt.Errorf("Validators count is not meet the minimum official validator count (%v)", core.OFFICIAL_MINIMUM_VALIDATORS)
+	if officialMinimumValidators := core.OfficialMinimumValidators(stateDB); minValidators < officialMinimumValidators {
+		return fmt.Errorf("Validators count is not meet the minimum official validator count (%v)", officialMinimumValidators)
 	}
 
 	// Check the minimum deposit amount
-	officialMinimumDeposit := math.MustParseBig256(core.OFFICIAL_MINIMUM_DEPOSIT)
+	officialMinimumDeposit := core.OfficialMinimumDeposit(stateDB)
 	if minDepositAmount.Cmp(officialMinimumDeposit) == -1 {
 		return fmt.Errorf("Deposit amount is not meet the minimum official deposit amount (%v PI)", new(big.Int).Div(officialMinimumDeposit, big.NewInt(params.PI)))
 	}
 
 	// Check the startup cost
-	if startupCost.Cmp(officialMinimumDeposit) != 0 {
-		return fmt.Errorf("Startup cost is not meet the required amount (%v PI)", new(big.Int).Div(officialMinimumDeposit, big.NewInt(params.PI)))
+	startupCostRequired := math.MustParseBig256(core.OFFICIAL_MINIMUM_DEPOSIT)
+	if startupCost.Cmp(startupCostRequired) != 0 {
+		return fmt.Errorf("Startup cost is not meet the required amount (%v PI)", new(big.Int).Div(startupCostRequired, big.NewInt(params.PI)))
 	}
 
 	// Check start/end block
@@ -215,6 +219,27 @@ func (cch *CrossChainHelper) JoinChildChain(from common.Address, pubkey crypto.P
 	return nil
 }
 
+// ValidateSetChildChainForks checks the owner sets the fork schedule before any validator joins the child chain
+func (cch *CrossChainHelper) ValidateSetChildChainForks(from common.Address, chainId string) error {
+
+	ci := core.GetPendingChildChainData(cch.chainInfoDB, chainId)
+	if ci == nil {
+		if core.GetChainInfo(cch.chainInfoDB, chainId) != nil {
+			return fmt.Errorf("child chain %s has already started, the fork schedule can't be changed", chainId)
+		}
+		return fmt.Errorf("child chain %s not exist", chainId)
+	}
+
+	if from != ci.Owner {
+		return errors.New("only the owner can set the fork schedule of the child chain")
+	}
+
+	if len(ci.JoinedValidators) > 0 {
+		return fmt.Errorf("validators have joined child chain %s, the fork schedule can't be changed", chainId)
+	}
+	return nil
+}
+
 func (cch *CrossChainHelper) ReadyForLaunchChildChain(height *big.Int, stateDB *state.StateDB) ([]string, []byte, []string) {
 	log.Debug("ReadyForLaunchChildChain - start")
 
@@ -282,6 +307,14 @@ func (cch *CrossChainHelper) GetHeightFromMainChain() *big.Int {
 	return ethereum.BlockChain().CurrentBlock().Number()
 }
 
+func (cch *CrossChainHelper) IsSd2mc(chainId string) bool {
+	height := cch.GetHeightFromMainChain()
+	if forks := core.GetChildChainForks(cch.chainInfoDB, chainId); forks != nil {
+		return forks.IsSd2mcV1(height)
+	}
+	return params.IsSd2mc(cch.GetMainChainId(), height)
+}
+
 func (cch *CrossChainHelper) GetTxFromMainChain(txHash common.Hash) *types.Transaction {
 	ethereum := MustGetEthereumFromNode(chainMgr.mainChain.EthNode)
 	chainDb := ethereum.ChainDb()
@@ -291,12 +324,21 @@ func (cch *CrossChainHelper) GetTxFromMainChain(txHash common.Hash) *types.Trans
 }
 
 func (cch *CrossChainHelper) GetEpochFromMainChain() (string, *epoch.Epoch) {
-	ethereum := MustGetEthereumFromNode(chainMgr.mainChain.EthNode)
+	var engine consensus.Engine
+	var chainConfig *params.ChainConfig
+	if ethereum, err := getEthereumFromNode(chainMgr.mainChain.EthNode); err == nil {
+		engine, chainConfig = ethereum.Engine(), ethereum.ChainConfig()
+	} else {
+		// The main chain runs in light mode, the engine follows the epochs from the headers
+		lightEthereum := MustGetLightEthereumFromNode(chainMgr.mainChain.EthNode)
+		engine, chainConfig = lightEthereum.Engine(), lightEthereum.BlockChain().Config()
+	}
+
 	var ep *epoch.Epoch
-	if tdm, ok := ethereum.Engine().(consensus.Tendermint); ok {
+	if tdm, ok := engine.(consensus.Tendermint); ok {
 		ep = tdm.GetEpoch()
 	}
-	return ethereum.ChainConfig().PChainId, ep
+	return chainConfig.PChainId, ep
 }
 
 func (cch *CrossChainHelper) ChangeValidators(chainId string) {
@@ -339,30 +381,15 @@ func (cch *CrossChainHelper) VerifyChildChainProofData(bs []byte) error {
 		//return errors.New("block in the future")
 	}
 
-	tdmExtra, err := tdmTypes.ExtractTendermintExtra(header)
+	tdmExtra, err := lightclient.CheckHeader(header)
 	if err != nil {
 		return err
 	}
 
 	chainId := tdmExtra.ChainID
-	if chainId == "" || chainId == MainChain || chainId == TestnetChain {
-		return fmt.Errorf("invalid child chain id: %s", chainId)
-	}
-
-	if header.Nonce != (types.TendermintEmptyNonce) && !bytes.Equal(header.Nonce[:], types.TendermintNonce) {
-		return errors.New("invalid nonce")
-	}
-
-	if header.MixDigest != types.TendermintDigest {
-		return errors.New("invalid mix digest")
-	}
-
-	if header.UncleHash != types.TendermintNilUncleHash {
-		return errors.New("invalid uncle Hash")
-	}
-
-	if header.Difficulty == nil || header.Difficulty.Cmp(types.TendermintDefaultDifficulty) != 0 {
-		return errors.New("invalid difficulty")
+	// nothing comes after the final block of the retired child chain
+	if rci := core.GetRetiredChainInfo(cch.chainInfoDB, chainId); rci != nil && tdmExtra.Height > rci.FinalHeight {
+		return fmt.Errorf("child chain %s has retired at block %v", chainId, rci.FinalHeight)
 	}
 
 	// special case: epoch 0 update
@@ -391,17 +418,7 @@ func (cch *CrossChainHelper) VerifyChildChainProofData(bs []byte) error {
 			core.SaveChainInfo(cch.chainInfoDB, ci)
 		}
 
-		valSet := epoch.Validators
-		if !bytes.Equal(valSet.Hash(), tdmExtra.ValidatorsHash) {
-			return errors.New("inconsistent validator set")
-		}
-
-		seenCommit := tdmExtra.SeenCommit
-		if !bytes.Equal(tdmExtra.SeenCommitHash, seenCommit.Hash()) {
-			return errors.New("invalid committed seals")
-		}
-
-		if err = valSet.VerifyCommit(tdmExtra.ChainID, tdmExtra.Height, seenCommit); err != nil {
+		if err = lightclient.VerifyCommit(tdmExtra, epoch.Validators); err != nil {
 			return err
 		}
 	}
@@ -468,30 +485,15 @@ func (cch *CrossChainHelper) ValidateTX3ProofData(proofData *types.TX3ProofData)
 		//return errors.New("block in the future")
 	}
 
-	tdmExtra, err := tdmTypes.ExtractTendermintExtra(header)
+	tdmExtra, err := lightclient.CheckHeader(header)
 	if err != nil {
 		return err
 	}
 
 	chainId := tdmExtra.ChainID
-	if chainId == "" || chainId == MainChain || chainId == TestnetChain {
-		return fmt.Errorf("invalid child chain id: %s", chainId)
-	}
-
-	if header.Nonce != (types.TendermintEmptyNonce) && !bytes.Equal(header.Nonce[:], types.TendermintNonce) {
-		return errors.New("invalid nonce")
-	}
-
-	if header.MixDigest != types.TendermintDigest {
-		return errors.New("invalid mix digest")
-	}
-
-	if header.UncleHash != types.TendermintNilUncleHash {
-		return errors.New("invalid uncle Hash")
-	}
-
-	if header.Difficulty == nil || header.Difficulty.Cmp(types.TendermintDefaultDifficulty) != 0 {
-		return errors.New("invalid difficulty")
+	// nothing comes after the final block of the retired child chain
+	if rci := core.GetRetiredChainInfo(cch.chainInfoDB, chainId); rci != nil && tdmExtra.Height > rci.FinalHeight {
+		return fmt.Errorf("child chain %s has retired at block %v", chainId, rci.FinalHeight)
 	}
 
 	// special case: epoch 0 update
@@ -518,27 +520,13 @@ func (cch *CrossChainHelper) ValidateTX3ProofData(proofData *types.TX3ProofData)
 		core.SaveChainInfo(cch.chainInfoDB, ci)
 	}
 
-	valSet := epoch.Validators
-	if !bytes.Equal(valSet.Hash(), tdmExtra.ValidatorsHash) {
-		return errors.New("inconsistent validator set")
-	}
-
-	seenCommit := tdmExtra.SeenCommit
-	if !bytes.Equal(tdmExtra.SeenCommitHash, seenCommit.Hash()) {
-		return errors.New("invalid committed seals")
-	}
-
-	if err = valSet.VerifyCommit(tdmExtra.ChainID, tdmExtra.Height, seenCommit); err != nil {
+	if err = lightclient.VerifyCommit(tdmExtra, epoch.Validators); err != nil {
 		return err
 	}
 
 	// tx merkle proof verify
-	keybuf := new(bytes.Buffer)
 	for i, txIndex := range proofData.TxIndexs {
-		keybuf.Reset()
-		rlp.Encode(keybuf, uint(txIndex))
-		_, _, err := trie.VerifyProof(header.TxHash, keybuf.Bytes(), proofData.TxProofs[i])
-		if err != nil {
+		if _, err := lightclient.VerifyTx(header, txIndex, proofData.TxProofs[i]); err != nil {
 			return err
 		}
 	}
@@ -624,30 +612,15 @@ func (cch *CrossChainHelper) VerifyChildChainProofDataV1(proofData *types.ChildC
 		//return errors.New("block in the future")
 	}
 
-	tdmExtra, err := tdmTypes.ExtractTendermintExtra(header)
+	tdmExtra, err := lightclient.CheckHeader(header)
 	if err != nil {
 		return err
 	}
 
 	chainId := tdmExtra.ChainID
-	if chainId == "" || chainId == MainChain || chainId == TestnetChain {
-		return fmt.Errorf("invalid child chain id: %s", chainId)
-	}
-
-	if header.Nonce != (types.TendermintEmptyNonce) && !bytes.Equal(header.Nonce[:], types.TendermintNonce) {
-		return errors.New("invalid nonce")
-	}
-
-	if header.MixDigest != types.TendermintDigest {
-		return errors.New("invalid mix digest")
-	}
-
-	if header.UncleHash != types.TendermintNilUncleHash {
-		return errors.New("invalid uncle Hash")
-	}
-
-	if header.Difficulty == nil || header.Difficulty.Cmp(types.TendermintDefaultDifficulty) != 0 {
-		return errors.New("invalid difficulty")
+	// nothing comes after the final block of the retired child chain
+	if rci := core.GetRetiredChainInfo(cch.chainInfoDB, chainId); rci != nil && tdmExtra.Height > rci.FinalHeight {
+		return fmt.Errorf("child chain %s has retired at block %v", chainId, rci.FinalHeight)
 	}
 
 	ci := core.GetChainInfo(cch.chainInfoDB, chainId)
@@ -655,7 +628,7 @@ func (cch *CrossChainHelper) VerifyChildChainProofDataV1(proofData *types.ChildC
 		return fmt.Errorf("chain info %s not found", chainId)
 	}
 
-	isSd2mc := params.IsSd2mc(cch.GetMainChainId(), cch.GetHeightFromMainChain())
+	isSd2mc := cch.IsSd2mc(chainId)
 	// Bypass the validator check for official child chain 0
 	if chainId != "child_0" || isSd2mc {
 
@@ -701,28 +674,15 @@ func (cch *CrossChainHelper) VerifyChildChainProofDataV1(proofData *types.ChildC
 			valSet = ep.Validators
 		}
 
-		if !bytes.Equal(valSet.Hash(), tdmExtra.ValidatorsHash) {
-			return errors.New("inconsistent validator set")
-		}
-
-		seenCommit := tdmExtra.SeenCommit
-		if !bytes.Equal(tdmExtra.SeenCommitHash, seenCommit.Hash()) {
-			return errors.New("invalid committed seals")
-		}
-
-		if err = valSet.VerifyCommit(tdmExtra.ChainID, tdmExtra.Height, seenCommit); err != nil {
+		if err = lightclient.VerifyCommit(tdmExtra, valSet); err != nil {
 			return err
 		}
 	}
 
 	//Verify Tx3
 	// tx merkle proof verify
-	keybuf := new(bytes.Buffer)
 	for i, txIndex := range proofData.TxIndexs {
-		keybuf.Reset()
-		rlp.Encode(keybuf, uint(txIndex))
-		_, _, err := trie.VerifyProof(header.TxHash, keybuf.Bytes(), proofData.TxProofs[i])
-		if err != nil {
+		if _, err := lightclient.VerifyTx(header, txIndex, proofData.TxProofs[i]); err != nil {
 			return err
 		}
 	}
@@ -811,6 +771,115 @@ func (cch *CrossChainHelper) GetAllTX3ProofData() []*types.TX3ProofData {
 
 // TX3LocalCache end
 
+// MainChainOutbox start
+func (cch *CrossChainHelper) GetOutboxEntry(chainId string, height uint64, kind uint64) *rawdb.OutboxEntry {
+	return rawdb.GetOutboxEntry(cch.outboxDB, chainId, height, kind)
+}
+
+func (cch *CrossChainHelper) GetOutboxEntries(chainId string) []*rawdb.OutboxEntry {
+	return rawdb.GetOutboxEntries(cch.outboxDB, chainId)
+}
+
+func (cch *CrossChainHelper) WriteOutboxEntry(entry *rawdb.OutboxEntry) error {
+	return rawdb.WriteOutboxEntry(cch.outboxDB, entry)
+}
+
+func (cch *CrossChainHelper) DeleteOutboxEntry(chainId string, height uint64, kind uint64) {
+	rawdb.DeleteOutboxEntry(cch.outboxDB, chainId, height, kind)
+}
+
+// MainChainOutbox end
+
+// TokenBridge start
+func (cch *CrossChainHelper) ValidateTokenTX1(from common.Address, txHash common.Hash) (*pabi.DepositTokenInMainChainArgs, error) {
+	tx := cch.GetTxFromMainChain(txHash)
+	if tx == nil {
+		return nil, fmt.Errorf("tx %x does not exist in main chain", txHash)
+	}
+
+	var args pabi.DepositTokenInMainChainArgs
+	if err := unpackFirstLegTx(tx, from, pabi.DepositTokenInMainChain, &args); err != nil {
+		return nil, err
+	}
+	return &args, nil
+}
+
+func (cch *CrossChainHelper) ValidateTokenTX3(from common.Address, chainId string, txHash common.Hash) (*pabi.WithdrawTokenFromChildChainArgs, error) {
+	// the tx3 is cached only after its proof data is verified
+	tx := cch.GetTX3(chainId, txHash)
+	if tx == nil {
+		return nil, fmt.Errorf("tx %x does not exist in child chain %s", txHash, chainId)
+	}
+
+	var args pabi.WithdrawTokenFromChildChainArgs
+	if err := unpackFirstLegTx(tx, from, pabi.WithdrawTokenFromChildChain, &args); err != nil {
+		return nil, err
+	}
+	if args.ChainId != chainId {
+		return nil, errors.New("params are not consistent with tx in child chain")
+	}
+	return &args, nil
+}
+
+// TokenBridge end
+
+// ChildChainTransfer start
+
+// ValidateChildChainTransfer returns the TransferToChildChain tx of the child chain and its amount, the transfer must be
+// settled in the main chain at the main chain block, otherwise it's refunded there
+func (cch *CrossChainHelper) ValidateChildChainTransfer(from common.Address, chainId string, txHash common.Hash, mainBlockNumber *big.Int) (*pabi.TransferToChildChainArgs, *big.Int, error) {
+	// the tx3 is cached only after its proof data is verified
+	tx := cch.GetTX3(chainId, txHash)
+	if tx == nil {
+		return nil, nil, fmt.Errorf("tx %x does not exist in child chain %s", txHash, chainId)
+	}
+
+	var args pabi.TransferToChildChainArgs
+	if err := unpackFirstLegTx(tx, from, pabi.TransferToChildChain, &args); err != nil {
+		return nil, nil, err
+	}
+
+	// the main chain is read at the block given by the child chain block, not at the local head
+	mainChain := MustGetEthereumFromNode(chainMgr.mainChain.EthNode).BlockChain()
+	mainHeader := mainChain.GetHeaderByNumber(mainBlockNumber.Uint64())
+	if mainHeader == nil {
+		return nil, nil, fmt.Errorf("main chain block %v not found", mainBlockNumber)
+	}
+	mainState, err := mainChain.StateAt(mainHeader.Root)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !mainState.HasTX1(from, txHash) {
+		return nil, nil, fmt.Errorf("tx %x is not settled in main chain", txHash)
+	}
+	return &args, tx.Value(), nil
+}
+
+// ChildChainTransfer end
+
+// unpackFirstLegTx checks the first leg of the transfer is sent by the address, and unpacks its arguments
+func unpackFirstLegTx(tx *types.Transaction, from common.Address, function pabi.FunctionType, args interface{}) error {
+	if !pabi.IsPChainContractAddr(tx.To()) || len(tx.Data()) < 4 {
+		return errors.New("invalid tx: wrong To()")
+	}
+
+	data := tx.Data()
+	if f, err := pabi.FunctionTypeFromId(data[:4]); err != nil || f != function {
+		return errors.New("invalid tx: wrong function")
+	}
+
+	signer := types.NewEIP155Signer(tx.ChainId())
+	txFrom, err := types.Sender(signer, tx)
+	if err != nil {
+		return core.ErrInvalidSender
+	}
+	if txFrom != from {
+		return errors.New("invalid tx: wrong sender")
+	}
+
+	return pabi.ChainABI.UnpackMethodInputs(args, function.String(), data[4:])
+}
+
 func MustGetEthereumFromNode(node *node.Node) *eth.Ethereum {
 	ethereum, err := getEthereumFromNode(node)
 	if err != nil {
@@ -827,3 +896,20 @@ func getEthereumFromNode(node *node.Node) (*eth.Ethereum, error) {
 
 	return ethereum, nil
 }
+
+func MustGetLightEthereumFromNode(node *node.Node) *les.LightEthereum {
+	lightEthereum, err := getLightEthereumFromNode(node)
+	if err != nil {
+		panic("getLightEthereumFromNode error: " + err.Error())
+	}
+	return lightEthereum
+}
+
+func getLightEthereumFromNode(node *node.Node) (*les.LightEthereum, error) {
+	var lightEthereum *les.LightEthereum
+	if err := node.Service(&lightEthereum); err != nil {
+		return nil, err
+	}
+
+	return lightEthereum, nil
+}
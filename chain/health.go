@@ -0,0 +1,113 @@
+package chain
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/eth"
+	"sort"
+	"time"
+)
+
+// A chain is reported unhealthy when its head block is older than this, or it has no peers
+const maxHealthyHeadAge = 5 * time.Minute
+
+// ChainHealth is the local status of one chain hosted by this node
+type ChainHealth struct {
+	ChainId string `json:"chainId"`
+	Healthy bool   `json:"healthy"`
+
+	HeadNumber hexutil.Uint64 `json:"headNumber"`
+	HeadHash   common.Hash    `json:"headHash"`
+	HeadAge    uint64         `json:"headAge"` // seconds since the head block
+
+	Peers int `json:"peers"`
+
+	Syncing       bool           `json:"syncing"`
+	StartingBlock hexutil.Uint64 `json:"startingBlock"`
+	CurrentBlock  hexutil.Uint64 `json:"currentBlock"`
+	HighestBlock  hexutil.Uint64 `json:"highestBlock"`
+
+	ConsensusStarted bool           `json:"consensusStarted"`
+	ConsensusHeight  hexutil.Uint64 `json:"consensusHeight"`
+	ConsensusRound   int            `json:"consensusRound"`
+	ConsensusStep    string         `json:"consensusStep"`
+
+	Validator   common.Address `json:"validator"`
+	IsValidator bool           `json:"isValidator"` // the local validator is in the current epoch
+}
+
+// ChainsHealth returns the status of the main chain and the running child chains, main chain first
+func (cm *ChainManager) ChainsHealth() []*ChainHealth {
+	chains := []*Chain{cm.mainChain}
+
+	cm.createChildChainLock.Lock()
+	childIds := make([]string, 0, len(cm.childChains))
+	for chainId := range cm.childChains {
+		childIds = append(childIds, chainId)
+	}
+	sort.Strings(childIds)
+	for _, chainId := range childIds {
+		chains = append(chains, cm.childChains[chainId])
+	}
+	cm.createChildChainLock.Unlock()
+
+	result := make([]*ChainHealth, 0, len(chains))
+	for _, chain := range chains {
+		if chain == nil || chain.EthNode == nil {
+			continue
+		}
+		result = append(result, chainHealth(chain))
+	}
+	return result
+}
+
+// Healthy reports whether all the chains hosted by this node are healthy, with the status of each chain
+func (cm *ChainManager) Healthy() (interface{}, bool) {
+	chains := cm.ChainsHealth()
+	healthy := len(chains) > 0
+	for _, h := range chains {
+		healthy = healthy && h.Healthy
+	}
+	return chains, healthy
+}
+
+func chainHealth(chain *Chain) *ChainHealth {
+	health := &ChainHealth{ChainId: chain.Id}
+
+	var ethereum *eth.Ethereum
+	if err := chain.EthNode.Service(&ethereum); err != nil || ethereum == nil {
+		return health
+	}
+
+	head := ethereum.BlockChain().CurrentBlock()
+	health.HeadNumber = hexutil.Uint64(head.NumberU64())
+	health.HeadHash = head.Hash()
+	if now, t := uint64(time.Now().Unix()), head.Time(); now > t {
+		health.HeadAge = now - t
+	}
+
+	health.Peers = ethereum.PeerCount()
+
+	progress := ethereum.Downloader().Progress()
+	health.Syncing = progress.CurrentBlock < progress.HighestBlock
+	health.StartingBlock = hexutil.Uint64(progress.StartingBlock)
+	health.CurrentBlock = hexutil.Uint64(progress.CurrentBlock)
+	health.HighestBlock = hexutil.Uint64(progress.HighestBlock)
+
+	if tdm, ok := ethereum.Engine().(consensus.Tendermint); ok {
+		health.ConsensusStarted = tdm.IsStarted()
+		height, round, step := tdm.GetRoundState()
+		health.ConsensusHeight = hexutil.Uint64(height)
+		health.ConsensusRound = round
+		health.ConsensusStep = step
+
+		health.Validator = tdm.PrivateValidator()
+		if ep := tdm.GetEpoch(); ep != nil && ep.Validators != nil {
+			health.IsValidator = ep.Validators.HasAddress(health.Validator[:])
+		}
+	}
+
+	health.Healthy = health.Peers > 0 && time.Duration(health.HeadAge)*time.Second < maxHealthyHeadAge
+	return health
+}
@@ -416,9 +416,10 @@ func checkAccount(coreGenesis core.Genesis) (common.Address, *big.Int, error) {
 	return act, amount, nil
 }
 
-func initEthGenesisFromExistValidator(childChainID string, childConfig cfg.Config, validators []types.GenesisValidator) error {
+func initEthGenesisFromExistValidator(childChainID string, childConfig cfg.Config, validators []types.GenesisValidator,
+	forks *params.ChildForkSchedule) error {
 
-	contents, err := generateETHGenesis(childChainID, validators)
+	contents, err := generateETHGenesis(childChainID, validators, forks)
 	if err != nil {
 		return err
 	}
@@ -430,9 +431,15 @@ func initEthGenesisFromExistValidator(childChainID string, childConfig cfg.Confi
 	return nil
 }
 
-func generateETHGenesis(childChainID string, validators []types.GenesisValidator) ([]byte, error) {
+// generateETHGenesis generates the genesis of the child chain, the fork schedule agreed on the main chain is written
+// into the chain config, nil for the default schedule
+func generateETHGenesis(childChainID string, validators []types.GenesisValidator, forks *params.ChildForkSchedule) ([]byte, error) {
+	config := params.NewChildChainConfig(childChainID)
+	config.ChildForks = forks
+	config.ApplyChildForks()
+
 	var coreGenesis = core.Genesis{
-		Config:     params.NewChildChainConfig(childChainID),
+		Config:     config,
 		Nonce:      0xdeadbeefdeadbeef,
 		Timestamp:  0x0,
 		ParentHash: common.Hash{},
@@ -0,0 +1,425 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	pabi "github.com/pchain/abi"
+	"math/big"
+	"path"
+	"sync"
+	"time"
+)
+
+const (
+	// The running child chains are checked for new transfers at this interval
+	relayInterval = 5 * time.Second
+	// The blocks scanned for new transfers of one chain at a time
+	maxScanBlocks = 1000
+	// The relayer gives up the transfer after failing to send the second leg this many times
+	maxRelayAttempts = 10
+)
+
+var (
+	ErrRelayerNotRunning = errors.New("cross chain relayer is not running")
+	ErrTransferNotFound  = errors.New("transfer not tracked by the relayer")
+)
+
+// Relayer completes the cross chain transfers sent by the accounts of this node. Once the first leg is packaged, it sends
+// the second leg (DepositInChildChain for a DepositInMainChain, WithdrawFromMainChain for a WithdrawFromChildChain,
+// ReceiveFromChildChain for a TransferToChildChain) signed by the same account, which must be unlocked on this node,
+// so the users only sign once.
+type Relayer struct {
+	cm *ChainManager
+	db ethdb.Database
+
+	mu      sync.Mutex
+	pending map[common.Hash]*rawdb.TransferRecord
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// StartRelayer starts the relayer of the cross chain transfers, the transfers not finished last time are resumed
+func (cm *ChainManager) StartRelayer() error {
+
+	// The light Main Chain doesn't process the blocks, there is no transfer to relay
+	if _, err := getLightEthereumFromNode(cm.mainChain.EthNode); err == nil {
+		return errors.New("cross chain relayer doesn't run on the light node")
+	}
+
+	db, err := rawdb.NewLevelDBDatabase(path.Join(cm.ctx.GlobalString(utils.DataDirFlag.Name), "relayer"), 0, 0, "pchain/db/relayer/")
+	if err != nil {
+		return err
+	}
+
+	r := &Relayer{
+		cm:      cm,
+		db:      db,
+		pending: make(map[common.Hash]*rawdb.TransferRecord),
+		quit:    make(chan struct{}),
+	}
+	for _, record := range rawdb.GetUnfinishedTransferRecords(db) {
+		r.pending[record.TxHash] = record
+	}
+	log.Infof("Cross chain relayer started, %v transfers to resume", len(r.pending))
+
+	cm.relayer = r
+	r.wg.Add(1)
+	go r.loop()
+	return nil
+}
+
+// Stop terminates the relayer and closes its database
+func (r *Relayer) Stop() {
+	close(r.quit)
+	r.wg.Wait()
+	r.db.Close()
+}
+
+func (r *Relayer) loop() {
+	defer r.wg.Done()
+
+	mainEth := MustGetEthereumFromNode(r.cm.mainChain.EthNode)
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	headSub := mainEth.BlockChain().SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	tx3Ch := make(chan core.Tx3ProofDataEvent, 10)
+	tx3Sub := mainEth.SubscribeTx3ProofDataEvent(tx3Ch)
+	defer tx3Sub.Unsubscribe()
+
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-headCh:
+		case <-tx3Ch:
+		case <-ticker.C:
+		case <-headSub.Err():
+			return
+		case <-tx3Sub.Err():
+			return
+		case <-r.quit:
+			return
+		}
+
+		r.scan(r.cm.mainChain.Id, mainEth, true)
+		for chainId, ethereum := range r.childChains() {
+			r.scan(chainId, ethereum, false)
+		}
+		r.relay()
+	}
+}
+
+// GetTransfer returns the transfer of the first leg tx
+func (r *Relayer) GetTransfer(txHash common.Hash) *rawdb.TransferRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.pending[txHash]; ok {
+		copied := *record
+		return &copied
+	}
+	return rawdb.GetTransferRecord(r.db, txHash)
+}
+
+// childChains returns the child chains running on this node
+func (r *Relayer) childChains() map[string]*eth.Ethereum {
+	r.cm.createChildChainLock.Lock()
+	defer r.cm.createChildChainLock.Unlock()
+
+	result := make(map[string]*eth.Ethereum, len(r.cm.childChains))
+	for chainId, chain := range r.cm.childChains {
+		if chain == nil || chain.EthNode == nil {
+			continue
+		}
+		if ethereum, err := getEthereumFromNode(chain.EthNode); err == nil {
+			result[chainId] = ethereum
+		}
+	}
+	return result
+}
+
+// scan tracks the first legs sent by the local accounts in the blocks after the last scanned one. The chain
+// never scanned before is scanned from the current head.
+func (r *Relayer) scan(chainId string, ethereum *eth.Ethereum, isMainChain bool) {
+	bc := ethereum.BlockChain()
+	head := bc.CurrentBlock().NumberU64()
+
+	last, ok := rawdb.ReadTransferScanHeight(r.db, chainId)
+	if !ok || last > head {
+		last = head
+	}
+	if head-last > maxScanBlocks {
+		head = last + maxScanBlocks
+	}
+
+	// The first legs are applied when the state has their markers
+	state, err := bc.State()
+	if err != nil {
+		log.Errorf("Relayer: can't get the state of chain %s, %v", chainId, err)
+		return
+	}
+
+	for number := last + 1; number <= head; number++ {
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			break
+		}
+		for _, tx := range block.Transactions() {
+			if !pabi.IsPChainContractAddr(tx.To()) || len(tx.Data()) < 4 {
+				continue
+			}
+			function, err := pabi.FunctionTypeFromId(tx.Data()[:4])
+			if err != nil {
+				continue
+			}
+
+			from, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
+			if err != nil || !r.isLocalAccount(from) {
+				continue
+			}
+
+			if isMainChain && function == pabi.DepositInMainChain && state.HasTX1(from, tx.Hash()) {
+				var args pabi.DepositInMainChainArgs
+				if err := pabi.ChainABI.UnpackMethodInputs(&args, pabi.DepositInMainChain.String(), tx.Data()[4:]); err != nil {
+					continue
+				}
+				r.track(rawdb.TransferDeposit, tx, from, args.ChainId, number)
+			} else if !isMainChain && function == pabi.WithdrawFromChildChain && state.HasTX3(from, tx.Hash()) {
+				r.track(rawdb.TransferWithdraw, tx, from, chainId, number)
+			} else if !isMainChain && function == pabi.TransferToChildChain && state.HasTX3(from, tx.Hash()) {
+				r.track(rawdb.TransferChildToChild, tx, from, chainId, number)
+			}
+		}
+		last = number
+	}
+
+	if err := rawdb.WriteTransferScanHeight(r.db, chainId, last); err != nil {
+		log.Errorf("Relayer: can't write the scan height of chain %s, %v", chainId, err)
+	}
+}
+
+func (r *Relayer) track(kind uint64, tx *types.Transaction, from common.Address, chainId string, height uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[tx.Hash()]; ok || rawdb.GetTransferRecord(r.db, tx.Hash()) != nil {
+		return
+	}
+
+	record := &rawdb.TransferRecord{
+		TxHash:  tx.Hash(),
+		Kind:    kind,
+		ChainId: chainId,
+		From:    from,
+		Amount:  tx.Value(),
+		Height:  height,
+		Status:  rawdb.TransferPending,
+	}
+	r.writeRecord(record)
+	r.pending[record.TxHash] = record
+	log.Infof("Relayer: tracking transfer %x of %x in chain %s, amount %v", record.TxHash, from, chainId, record.Amount)
+}
+
+// relay sends the second legs which are ready and not sent yet, and finishes the completed transfers
+func (r *Relayer) relay() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mainEth := MustGetEthereumFromNode(r.cm.mainChain.EthNode)
+	childChains := r.childChains()
+
+	for txHash, record := range r.pending {
+		var (
+			target *eth.Ethereum
+			input  []byte
+			err    error
+		)
+
+		switch record.Kind {
+		case rawdb.TransferDeposit:
+			// The deposit is completed in the child chain, which must run on this node
+			target = childChains[record.ChainId]
+			if target == nil {
+				record.LastError = fmt.Sprintf("child chain %s not running on this node", record.ChainId)
+				continue
+			}
+			if state, err := target.BlockChain().State(); err != nil || state.HasTX1(record.From, txHash) {
+				if err == nil {
+					r.finish(record, rawdb.TransferCompleted)
+				}
+				continue
+			}
+			input, err = pabi.ChainABI.Pack(pabi.DepositInChildChain.String(), record.ChainId, txHash)
+		case rawdb.TransferWithdraw:
+			// The withdrawal is completed in the main chain, after the tx3 proof data has arrived
+			target = mainEth
+			if state, err := target.BlockChain().State(); err != nil || state.HasTX3(record.From, txHash) {
+				if err == nil {
+					r.finish(record, rawdb.TransferCompleted)
+				}
+				continue
+			}
+			if r.cm.cch.GetTX3(record.ChainId, txHash) == nil {
+				continue
+			}
+			input, err = pabi.ChainABI.Pack(pabi.WithdrawFromMainChain.String(), record.ChainId, record.Amount, txHash)
+		case rawdb.TransferChildToChild:
+			// The transfer is received in the target child chain, which must run on this node, after the main chain
+			// has settled it with the tx3 proof data
+			tx3 := r.cm.cch.GetTX3(record.ChainId, txHash)
+			if tx3 == nil {
+				continue
+			}
+			var args pabi.TransferToChildChainArgs
+			if err := pabi.ChainABI.UnpackMethodInputs(&args, pabi.TransferToChildChain.String(), tx3.Data()[4:]); err != nil {
+				r.fail(record, err)
+				continue
+			}
+			target = childChains[args.ChainId]
+			if target == nil {
+				record.LastError = fmt.Sprintf("child chain %s not running on this node", args.ChainId)
+				continue
+			}
+			if state, err := target.BlockChain().State(); err != nil || state.HasTX1(record.From, txHash) {
+				if err == nil {
+					r.finish(record, rawdb.TransferCompleted)
+				}
+				continue
+			}
+			if state, err := mainEth.BlockChain().State(); err != nil || !state.HasTX1(record.From, txHash) {
+				// The main chain refunds the transfer when the target chain is not running
+				if err == nil && state.HasTX3(record.From, txHash) {
+					record.LastError = fmt.Sprintf("refunded in the main chain, child chain %s not running", args.ChainId)
+					r.finish(record, rawdb.TransferFailed)
+				}
+				continue
+			}
+			input, err = pabi.ChainABI.Pack(pabi.ReceiveFromChildChain.String(), record.ChainId, txHash)
+		}
+		if err != nil {
+			r.fail(record, err)
+			continue
+		}
+
+		if record.Status == rawdb.TransferSubmitted {
+			// Wait for the second leg in the tx pool, it's sent again if dropped or packaged without completing the transfer
+			if target.TxPool().Get(record.SecondTxHash) != nil {
+				continue
+			}
+			if tx, _, _, _ := rawdb.ReadTransaction(target.ChainDb(), record.SecondTxHash); tx != nil {
+				r.fail(record, fmt.Errorf("second leg %x packaged without completing the transfer", record.SecondTxHash))
+				if record.Status == rawdb.TransferFailed {
+					continue
+				}
+			}
+		}
+
+		hash, err := r.send(target, record.From, input)
+		if err != nil {
+			r.fail(record, err)
+			continue
+		}
+		record.Status = rawdb.TransferSubmitted
+		record.SecondTxHash = hash
+		record.LastError = ""
+		r.writeRecord(record)
+		log.Infof("Relayer: second leg %x of transfer %x sent", hash, txHash)
+	}
+}
+
+// send signs the second leg with the account of the first leg and adds it to the tx pool of the target chain
+func (r *Relayer) send(target *eth.Ethereum, from common.Address, input []byte) (common.Hash, error) {
+	ctx := context.Background()
+
+	nonce, err := target.ApiBackend.GetPoolNonce(ctx, from)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	gasPrice, err := target.ApiBackend.SuggestPrice(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	// The second legs take no gas, the same as sent by the RPC
+	tx := types.NewTransaction(nonce, pabi.ChainContractMagicAddr, new(big.Int), 0, gasPrice, input)
+	signed, err := r.signTx(from, tx, target.ChainConfig().ChainId)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := target.ApiBackend.SendTx(ctx, signed); err != nil {
+		return common.Hash{}, err
+	}
+	return signed.Hash(), nil
+}
+
+// signTx signs the tx with the account found in the account managers of the chains on this node
+func (r *Relayer) signTx(from common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	account := accounts.Account{Address: from}
+	err := fmt.Errorf("account %x not found", from)
+	for _, am := range r.accountManagers() {
+		wallet, findErr := am.Find(account)
+		if findErr != nil {
+			continue
+		}
+		var signed *types.Transaction
+		if signed, err = wallet.SignTx(account, tx, chainId); err == nil {
+			return signed, nil
+		}
+	}
+	return nil, err
+}
+
+func (r *Relayer) isLocalAccount(addr common.Address) bool {
+	for _, am := range r.accountManagers() {
+		if _, err := am.Find(accounts.Account{Address: addr}); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Relayer) accountManagers() []*accounts.Manager {
+	managers := []*accounts.Manager{r.cm.mainChain.EthNode.AccountManager()}
+	for _, ethereum := range r.childChains() {
+		managers = append(managers, ethereum.AccountManager())
+	}
+	return managers
+}
+
+func (r *Relayer) fail(record *rawdb.TransferRecord, err error) {
+	record.Attempts++
+	record.LastError = err.Error()
+	log.Warnf("Relayer: transfer %x attempt %v failed, %v", record.TxHash, record.Attempts, err)
+	if record.Attempts >= maxRelayAttempts {
+		r.finish(record, rawdb.TransferFailed)
+		return
+	}
+	r.writeRecord(record)
+}
+
+func (r *Relayer) finish(record *rawdb.TransferRecord, status uint64) {
+	record.Status = status
+	r.writeRecord(record)
+	delete(r.pending, record.TxHash)
+	log.Infof("Relayer: transfer %x finished, status %v", record.TxHash, status)
+}
+
+func (r *Relayer) writeRecord(record *rawdb.TransferRecord) {
+	if err := rawdb.WriteTransferRecord(r.db, record); err != nil {
+		log.Errorf("Relayer: can't write transfer %x, %v", record.TxHash, err)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/urfave/cli.v1"
+	"path/filepath"
+)
+
+var (
+	childChainCommand = cli.Command{
+		Name:     "child_chain",
+		Usage:    "Manage the child chains of a running pchain node",
+		Category: "CHILD CHAIN COMMANDS",
+		Description: `
+
+Stop a misbehaving child chain or start it again while the main chain and the
+other child chains keep running.
+
+The commands talk to the running node through the IPC endpoint of the main chain
+(<DATADIR>/pchain/pchain.ipc by default), an HTTP/WS endpoint with the admin api
+enabled could be given as the last argument instead.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "stop",
+				Usage:     "Stop a running child chain",
+				Action:    utils.MigrateFlags(stopChildChainCmd),
+				ArgsUsage: "<chainId> [endpoint]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.TestnetFlag,
+				},
+				Description: `
+    pchain child_chain stop <chainId>
+
+Stops the child chain, detaches it from the P2P server and the RPC endpoints.`,
+			},
+			{
+				Name:      "start",
+				Usage:     "Start a stopped child chain",
+				Action:    utils.MigrateFlags(startChildChainCmd),
+				ArgsUsage: "<chainId> [endpoint]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.TestnetFlag,
+				},
+				Description: `
+    pchain child_chain start <chainId>
+
+Loads the child chain again and hooks it up to the P2P server and the RPC endpoints.`,
+			},
+			{
+				Name:      "list",
+				Usage:     "Print the child chains running on the node",
+				Action:    utils.MigrateFlags(listChildChainCmd),
+				ArgsUsage: "[endpoint]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.TestnetFlag,
+				},
+				Description: `
+    pchain child_chain list`,
+			},
+		},
+	}
+)
+
+func stopChildChainCmd(ctx *cli.Context) error {
+	return callChildChainAdmin(ctx, "admin_stopChildChain")
+}
+
+func startChildChainCmd(ctx *cli.Context) error {
+	return callChildChainAdmin(ctx, "admin_startChildChain")
+}
+
+func callChildChainAdmin(ctx *cli.Context, method string) error {
+	chainId := ctx.Args().First()
+	if chainId == "" {
+		utils.Fatalf("child chain id must be given as argument")
+	}
+
+	client, err := dialMainChain(ctx, ctx.Args().Get(1))
+	if err != nil {
+		utils.Fatalf("Unable to attach to pchain node: %v", err)
+	}
+	defer client.Close()
+
+	var result bool
+	if err := client.Call(&result, method, chainId); err != nil {
+		utils.Fatalf("%v failed: %v", method, err)
+	}
+	fmt.Printf("%v %v: %v\n", method, chainId, result)
+	return nil
+}
+
+func listChildChainCmd(ctx *cli.Context) error {
+	client, err := dialMainChain(ctx, ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Unable to attach to pchain node: %v", err)
+	}
+	defer client.Close()
+
+	var chainIds []string
+	if err := client.Call(&chainIds, "admin_childChains"); err != nil {
+		utils.Fatalf("admin_childChains failed: %v", err)
+	}
+	for _, chainId := range chainIds {
+		fmt.Println(chainId)
+	}
+	return nil
+}
+
+// dialMainChain connects to the given endpoint or the IPC endpoint of the main chain
+func dialMainChain(ctx *cli.Context, endpoint string) (*rpc.Client, error) {
+	if endpoint == "" {
+		chainId := params.MainnetChainConfig.PChainId
+		if ctx.GlobalBool(utils.TestnetFlag.Name) {
+			chainId = params.TestnetChainConfig.PChainId
+		}
+		endpoint = filepath.Join(utils.MakeDataDir(ctx), chainId, clientIdentifier+".ipc")
+	}
+	return rpc.Dial(endpoint)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/cmd/geth"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/datareduction"
+	"github.com/ethereum/go-ethereum/params"
+	"gopkg.in/urfave/cli.v1"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	pruneChainFlag = cli.StringFlag{
+		Name:  "chain",
+		Usage: "Chain id of the chain to prune (default the main chain)",
+	}
+	pruneKeepFlag = cli.Uint64Flag{
+		Name:  "keep",
+		Usage: "Number of the latest blocks of which the state is retained",
+		Value: 10000,
+	}
+	pruneBodiesFlag = cli.BoolFlag{
+		Name:  "bodies",
+		Usage: "Delete the bodies of the blocks older than the retained blocks as well",
+	}
+
+	dbCommand = cli.Command{
+		Name:     "db",
+		Usage:    "Maintain the chain data of a stopped node",
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+The commands open the chain database of a chain directly, the node must be stopped.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "prune",
+				Usage:  "Prune the old states and compact the chain data",
+				Action: utils.MigrateFlags(pruneDBCmd),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.TestnetFlag,
+					pruneChainFlag,
+					pruneKeepFlag,
+					pruneBodiesFlag,
+				},
+				Description: `
+    pchain db prune --chain <chainId> --keep <blocks> [--bodies]
+
+Deletes the states of the blocks older than the latest <blocks> blocks, and their
+bodies with --bodies, then compacts the chain database. An interrupted prune is
+resumed by running the command again.`,
+			},
+		},
+	}
+)
+
+func pruneDBCmd(ctx *cli.Context) error {
+	chainId := ctx.String(pruneChainFlag.Name)
+	if chainId == "" {
+		chainId = params.MainnetChainConfig.PChainId
+		if ctx.GlobalBool(utils.TestnetFlag.Name) {
+			chainId = params.TestnetChainConfig.PChainId
+		}
+	}
+
+	stack, _ := gethmain.MakeConfigNode(ctx, chainId)
+	chainDir := stack.ResolvePath("chaindata")
+	if _, err := os.Stat(chainDir); err != nil {
+		utils.Fatalf("Failed to open the chain data of chain %v: %v", chainId, err)
+	}
+	sizeBefore := dirSize(chainDir)
+
+	// The databases are locked by the running node
+	chainDb, err := stack.OpenDatabase("chaindata", 0, 0, "")
+	if err != nil {
+		utils.Fatalf("Failed to open the chain database, is the node stopped? %v", err)
+	}
+	defer chainDb.Close()
+	pruneDb, err := stack.OpenDatabase("prunedata", 0, 0, "")
+	if err != nil {
+		utils.Fatalf("Failed to open the prune database: %v", err)
+	}
+	defer pruneDb.Close()
+
+	start := time.Now()
+	result, err := datareduction.OfflinePrune(chainDb, pruneDb, ctx.Uint64(pruneKeepFlag.Name), ctx.Bool(pruneBodiesFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to prune chain %v: %v", chainId, err)
+	}
+	fmt.Printf("Pruned the states up to block %d (head %d): %d nodes deleted (%v), %d nodes retained, %d block bodies deleted\n",
+		result.Target, result.Head, result.DeletedNodes, common.StorageSize(result.DeletedBytes), result.KeptNodes, result.DeletedBodies)
+
+	fmt.Println("Compacting the chain database")
+	if err := chainDb.Compact(nil, nil); err != nil {
+		utils.Fatalf("Failed to compact the chain database: %v", err)
+	}
+
+	sizeAfter := dirSize(chainDir)
+	fmt.Printf("Chain data %v -> %v, %v reclaimed in %v\n", common.StorageSize(sizeBefore), common.StorageSize(sizeAfter),
+		common.StorageSize(sizeBefore-sizeAfter), common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// dirSize returns the total size of the files in the directory
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -23,6 +23,12 @@ var (
 		Usage: "Specify one or more child chain should be start. Ex: child-1,child-2",
 	}
 
+	// Cross Chain Relayer Flag
+	RelayerFlag = cli.BoolFlag{
+		Name:  "relayer",
+		Usage: "Complete the cross chain deposits and withdrawals sent by the unlocked accounts of this node",
+	}
+
 	// ----------------------------
 	// Tendermint Flags
 
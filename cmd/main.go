@@ -87,6 +87,11 @@ func main() {
 
 		//walletCommand,
 		accountCommand,
+
+		childChainCommand,
+		walCommand,
+		signerCommand,
+		dbCommand,
 	}
 	cliApp.HideVersion = true // we have a command to print the version
 
@@ -220,6 +225,7 @@ func newCliApp(version, usage string) *cli.App {
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
 		utils.RPCApiFlag,
+		utils.RPCChainApiFlag,
 		utils.RPCCORSDomainFlag,
 		utils.RPCVirtualHostsFlag,
 		// RPC WS Flag
@@ -227,6 +233,7 @@ func newCliApp(version, usage string) *cli.App {
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
 		utils.WSApiFlag,
+		utils.WSChainApiFlag,
 		utils.WSAllowedOriginsFlag,
 
 		utils.IPCDisabledFlag,
@@ -241,6 +248,7 @@ func newCliApp(version, usage string) *cli.App {
 
 		LogDirFlag,
 		ChildChainFlag,
+		RelayerFlag,
 
 		/*
 			//Tendermint flags
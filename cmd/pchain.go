@@ -66,6 +66,12 @@ func pchainCmd(ctx *cli.Context) error {
 		return err
 	}
 
+	if ctx.GlobalBool(RelayerFlag.Name) {
+		if err := chainMgr.StartRelayer(); err != nil {
+			log.Errorf("Start Cross Chain Relayer failed. %v", err)
+		}
+	}
+
 	err = chainMgr.StartRPC()
 	if err != nil {
 		log.Error("start rpc failed")
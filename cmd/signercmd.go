@@ -0,0 +1,108 @@
+package main
+
+import (
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/consensus/pdbft/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pchain/chain"
+	"gopkg.in/urfave/cli.v1"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var (
+	signerLaddrFlag = cli.StringFlag{
+		Name:  "signer.laddr",
+		Usage: "Listen address of the signer, unix://<path> or tcp://<host:port> (default: priv_validator_laddr in config.toml)",
+	}
+	signerKeyFileFlag = cli.StringFlag{
+		Name:  "signer.keyfile",
+		Usage: "The priv_validator.json holding the consensus key (default: priv_validator.json of the main chain)",
+	}
+	signerSecretFileFlag = cli.StringFlag{
+		Name:  "signer.secretfile",
+		Usage: "The secret shared with the node (default: priv_validator_secret_file in config.toml)",
+	}
+
+	signerCommand = cli.Command{
+		Action:   utils.MigrateFlags(signerCmd),
+		Name:     "signer",
+		Usage:    "Run the remote signer of the consensus key",
+		Category: "MISCELLANEOUS COMMANDS",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.TestnetFlag,
+			signerLaddrFlag,
+			signerKeyFileFlag,
+			signerSecretFileFlag,
+		},
+		Description: `
+    pchain signer --signer.laddr=unix:///path/to/signer.sock
+
+The reference remote signer, it holds the consensus key and signs the votes and
+proposals for the node which has priv_validator_laddr set in its config.toml,
+so the node never reads the consensus key from disk. Both sides authenticate each
+other with the secret in the secret file, which must be the same on both sides.`,
+	}
+)
+
+func signerCmd(ctx *cli.Context) error {
+	chainId := params.MainnetChainConfig.PChainId
+	if ctx.GlobalBool(utils.TestnetFlag.Name) {
+		chainId = params.TestnetChainConfig.PChainId
+	}
+	config := chain.GetTendermintConfig(chainId, ctx)
+
+	laddr := ctx.String(signerLaddrFlag.Name)
+	if laddr == "" {
+		laddr = config.GetString("priv_validator_laddr")
+	}
+	keyFile := ctx.String(signerKeyFileFlag.Name)
+	if keyFile == "" {
+		keyFile = config.GetString("priv_validator_file")
+	}
+	secretFile := ctx.String(signerSecretFileFlag.Name)
+	if secretFile == "" {
+		secretFile = config.GetString("priv_validator_secret_file")
+	}
+
+	network, address, err := types.ParseRemoteSignerAddr(laddr)
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	secret, err := types.LoadRemoteSignerSecret(secretFile)
+	if err != nil {
+		utils.Fatalf("Failed to load the secret: %v", err)
+	}
+	if _, err := os.Stat(keyFile); err != nil {
+		utils.Fatalf("Failed to load the key: %v", err)
+	}
+	privVal := types.LoadPrivValidator(keyFile)
+
+	if network == "unix" {
+		os.Remove(address)
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		utils.Fatalf("Failed to listen on %v: %v", laddr, err)
+	}
+	if network == "unix" {
+		os.Chmod(address, 0600)
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigc
+		listener.Close()
+	}()
+
+	logger := log.New("module", "signer")
+	logger.Info("Remote signer started", "laddr", laddr, "address", privVal.Address)
+	types.NewRemoteSignerServer(privVal, secret, listener, logger).Serve()
+	logger.Info("Remote signer stopped")
+	return nil
+}
@@ -153,11 +153,13 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCListenAddrFlag,
 			utils.RPCPortFlag,
 			utils.RPCApiFlag,
+			utils.RPCChainApiFlag,
 
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
 			utils.WSApiFlag,
+			utils.WSChainApiFlag,
 			utils.WSAllowedOriginsFlag,
 
 			utils.IPCDisabledFlag,
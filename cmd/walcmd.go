@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pchain/chain"
+	auto "github.com/tendermint/go-autofile"
+	"gopkg.in/urfave/cli.v1"
+	"io"
+	"os"
+)
+
+var (
+	walCommand = cli.Command{
+		Name:     "wal",
+		Usage:    "Inspect the consensus write-ahead log",
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+The consensus of each chain logs every message and timeout to its write-ahead log
+before processing it, the log is replayed on start to restore the round state.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "dump",
+				Usage:     "Print the write-ahead log of a chain",
+				Action:    utils.MigrateFlags(dumpWALCmd),
+				ArgsUsage: "[chainId]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.TestnetFlag,
+				},
+				Description: `
+    pchain wal dump [chainId]
+
+Prints the messages in the write-ahead log of the chain (the main chain by default),
+one json per line, the "#ENDHEIGHT: <height>" lines mark the end of the heights.`,
+			},
+		},
+	}
+)
+
+func dumpWALCmd(ctx *cli.Context) error {
+	chainId := ctx.Args().First()
+	if chainId == "" {
+		chainId = params.MainnetChainConfig.PChainId
+		if ctx.GlobalBool(utils.TestnetFlag.Name) {
+			chainId = params.TestnetChainConfig.PChainId
+		}
+	}
+
+	walFile := chain.GetTendermintConfig(chainId, ctx).GetString("cs_wal_file")
+	if _, err := os.Stat(walFile); err != nil {
+		utils.Fatalf("Failed to open the wal of chain %v: %v", chainId, err)
+	}
+
+	group, err := auto.OpenGroup(walFile)
+	if err != nil {
+		utils.Fatalf("Failed to open the wal %v: %v", walFile, err)
+	}
+	defer group.Head.Close()
+
+	reader, err := group.NewReader(group.ReadGroupInfo().MinIndex)
+	if err != nil {
+		utils.Fatalf("Failed to read the wal %v: %v", walFile, err)
+	}
+	defer reader.Close()
+
+	for {
+		line, err := reader.ReadLine()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			utils.Fatalf("Failed to read the wal %v: %v", walFile, err)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -55,7 +55,7 @@ func registerEthService(stack *node.Node, cfg *eth.Config, cliCtx *cli.Context,
 	var err error
 	if cfg.SyncMode == downloader.LightSync {
 		err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-			return les.New(ctx, cfg, nil, stack.GetLogger())
+			return les.New(ctx, cfg, cliCtx, cch, stack.GetLogger())
 		})
 	} else {
 		err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
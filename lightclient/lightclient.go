@@ -0,0 +1,186 @@
+// Package lightclient verifies the child chain headers, txs and receipts against the validators
+// recorded on the main chain, without running the child chain.
+package lightclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/pdbft/epoch"
+	tdmTypes "github.com/ethereum/go-ethereum/consensus/pdbft/types"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	dbm "github.com/tendermint/go-db"
+)
+
+// ValidatorSource provides the validators of the child chain who commit the block at the height
+type ValidatorSource interface {
+	Validators(chainId string, height uint64) (*tdmTypes.ValidatorSet, error)
+}
+
+// CheckHeader checks the header is sealed by PDBFT for a child chain, and returns its Tendermint extra data.
+// The commit is not verified here, see VerifyCommit.
+func CheckHeader(header *types.Header) (*tdmTypes.TendermintExtra, error) {
+	if header == nil {
+		return nil, errors.New("no header")
+	}
+
+	tdmExtra, err := tdmTypes.ExtractTendermintExtra(header)
+	if err != nil {
+		return nil, err
+	}
+
+	chainId := tdmExtra.ChainID
+	if chainId == "" || chainId == params.MainnetChainConfig.PChainId || chainId == params.TestnetChainConfig.PChainId {
+		return nil, fmt.Errorf("invalid child chain id: %s", chainId)
+	}
+
+	if header.Nonce != (types.TendermintEmptyNonce) && !bytes.Equal(header.Nonce[:], types.TendermintNonce) {
+		return nil, errors.New("invalid nonce")
+	}
+
+	if header.MixDigest != types.TendermintDigest {
+		return nil, errors.New("invalid mix digest")
+	}
+
+	if header.UncleHash != types.TendermintNilUncleHash {
+		return nil, errors.New("invalid uncle Hash")
+	}
+
+	if header.Difficulty == nil || header.Difficulty.Cmp(types.TendermintDefaultDifficulty) != 0 {
+		return nil, errors.New("invalid difficulty")
+	}
+
+	return tdmExtra, nil
+}
+
+// VerifyCommit checks the block is committed by +2/3 of the validators
+func VerifyCommit(tdmExtra *tdmTypes.TendermintExtra, valSet *tdmTypes.ValidatorSet) error {
+	if !bytes.Equal(valSet.Hash(), tdmExtra.ValidatorsHash) {
+		return errors.New("inconsistent validator set")
+	}
+
+	seenCommit := tdmExtra.SeenCommit
+	if !bytes.Equal(tdmExtra.SeenCommitHash, seenCommit.Hash()) {
+		return errors.New("invalid committed seals")
+	}
+
+	return valSet.VerifyCommit(tdmExtra.ChainID, tdmExtra.Height, seenCommit)
+}
+
+// VerifyHeader checks the header and its commit with the validators from the source
+func VerifyHeader(src ValidatorSource, header *types.Header) (*tdmTypes.TendermintExtra, error) {
+	tdmExtra, err := CheckHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	valSet, err := src.Validators(tdmExtra.ChainID, tdmExtra.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := VerifyCommit(tdmExtra, valSet); err != nil {
+		return nil, err
+	}
+	return tdmExtra, nil
+}
+
+// VerifyTx checks the merkle proof of the tx at the index against the tx root of the header, and returns the tx
+func VerifyTx(header *types.Header, index uint, proof ethdb.Reader) (*types.Transaction, error) {
+	val, err := verifyProof(header.TxHash, index, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx types.Transaction
+	if err := rlp.DecodeBytes(val, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// VerifyReceipt checks the merkle proof of the receipt at the index against the receipt root of the header, and returns the receipt
+func VerifyReceipt(header *types.Header, index uint, proof ethdb.Reader) (*types.Receipt, error) {
+	val, err := verifyProof(header.ReceiptHash, index, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	var receipt types.Receipt
+	if err := rlp.DecodeBytes(val, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+func verifyProof(root common.Hash, index uint, proof ethdb.Reader) ([]byte, error) {
+	if proof == nil {
+		return nil, errors.New("no proof")
+	}
+
+	key, err := rlp.EncodeToBytes(index)
+	if err != nil {
+		return nil, err
+	}
+
+	val, _, err := trie.VerifyProof(root, key, proof)
+	if err != nil {
+		return nil, err
+	}
+	if len(val) == 0 {
+		return nil, fmt.Errorf("no value for index %v in the proof", index)
+	}
+	return val, nil
+}
+
+// ProofFromNodes puts the trie nodes of a merkle proof into a proof set, with the hash of each node as the key
+func ProofFromNodes(nodes [][]byte) *types.BSKeyValueSet {
+	proof := types.MakeBSKeyValueSet()
+	for _, node := range nodes {
+		proof.Put(crypto.Keccak256(node), node)
+	}
+	return proof
+}
+
+// ChainInfoSource provides the validators from the child chain epochs saved in the chain info db of the main chain
+type ChainInfoSource struct {
+	db dbm.DB
+}
+
+func NewChainInfoSource(db dbm.DB) *ChainInfoSource {
+	return &ChainInfoSource{db: db}
+}
+
+// Validators implements ValidatorSource.Validators
+func (src *ChainInfoSource) Validators(chainId string, height uint64) (*tdmTypes.ValidatorSet, error) {
+	ci := core.GetChainInfo(src.db, chainId)
+	if ci == nil {
+		return nil, fmt.Errorf("chain info %s not found", chainId)
+	}
+
+	if ep := ci.GetEpochByBlockNumber(height); ep != nil {
+		return ep.Validators, nil
+	}
+
+	// the first epoch of the child chain is only kept in the genesis
+	_, tdmGenesis := core.LoadChainGenesis(src.db, chainId)
+	if tdmGenesis == nil {
+		return nil, fmt.Errorf("could not get epoch for block height %v", height)
+	}
+	coreGenesis, err := tdmTypes.GenesisDocFromJSON(tdmGenesis)
+	if err != nil {
+		return nil, err
+	}
+	ep := epoch.MakeOneEpoch(nil, &coreGenesis.CurrentEpoch, nil)
+	if ep == nil || height < ep.StartBlock || height > ep.EndBlock {
+		return nil, fmt.Errorf("could not get epoch for block height %v", height)
+	}
+	return ep.Validators, nil
+}
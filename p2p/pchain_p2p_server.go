@@ -73,3 +73,7 @@ func (srv *PChainP2PServer) AddLocalValidator(chainId string, address common.Add
 func (srv *PChainP2PServer) RemoveLocalValidator(chainId string, address common.Address) {
 	srv.server.RemoveLocalValidator(chainId, address)
 }
+
+func (srv *PChainP2PServer) DetachChildChain(childId string) {
+	srv.server.DetachChildChain(childId)
+}
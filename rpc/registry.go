@@ -0,0 +1,144 @@
+package rpc
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ChainEntry describes a chain served on the RPC endpoint, it's listed in the index of the endpoint
+type ChainEntry struct {
+	ChainId    string       `json:"chainId"`
+	NetworkId  uint64       `json:"networkId"`
+	EthChainId *hexutil.Big `json:"ethChainId"` // the chain id for EIP155 signing
+	Path       string       `json:"path"`
+}
+
+type registryEntry struct {
+	ChainEntry
+	handler *rpc.Server
+}
+
+// Registry routes the requests on the endpoint to the rpc handler of each chain by path,
+// the chains could be registered and unregistered at runtime
+type Registry struct {
+	name  string
+	mux   *http.ServeMux
+	serve func(handler *rpc.Server, w http.ResponseWriter, r *http.Request)
+
+	mu      sync.RWMutex
+	entries map[string]*registryEntry
+	// Paths already registered on the mux, http.ServeMux can not unregister a path
+	paths map[string]bool
+}
+
+// NewRegistry creates the registry on the mux, the root path of the mux serves the index of the chains
+func NewRegistry(name string, mux *http.ServeMux, serve func(handler *rpc.Server, w http.ResponseWriter, r *http.Request)) *Registry {
+	r := &Registry{
+		name:    name,
+		mux:     mux,
+		serve:   serve,
+		entries: make(map[string]*registryEntry),
+		paths:   make(map[string]bool),
+	}
+	mux.Handle("/", http.HandlerFunc(r.serveIndex))
+	return r
+}
+
+// Register serves the chain on the path of its chain id, the handler of the chain registered before is replaced
+func (r *Registry) Register(chain ChainEntry, handler *rpc.Server) {
+	chain.Path = "/" + chain.ChainId
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log.Infof("Hookup %s for (chainId, handler): (%v, %v)", r.name, chain.ChainId, handler)
+	if old, ok := r.entries[chain.ChainId]; ok && old.handler != handler {
+		old.handler.Stop()
+	}
+	r.entries[chain.ChainId] = &registryEntry{ChainEntry: chain, handler: handler}
+
+	if !r.paths[chain.Path] {
+		chainId := chain.ChainId
+		r.mux.Handle(chain.Path, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r.serveChain(chainId, w, req)
+		}))
+		r.paths[chain.Path] = true
+	}
+}
+
+// Unregister stops serving the chain, the path returns 404 until it is registered again
+func (r *Registry) Unregister(chainId string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.entries[chainId]; ok {
+		log.Infof("Unhook %s for chainId: %v", r.name, chainId)
+		delete(r.entries, chainId)
+		entry.handler.Stop()
+	}
+}
+
+// Chains returns the chains served on the endpoint, ordered by chain id
+func (r *Registry) Chains() []ChainEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chains := make([]ChainEntry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		chains = append(chains, entry.ChainEntry)
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].ChainId < chains[j].ChainId
+	})
+	return chains
+}
+
+// Stop stops the handlers of all the chains
+func (r *Registry) Stop() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, entry := range r.entries {
+		entry.handler.Stop()
+	}
+}
+
+func (r *Registry) serveChain(chainId string, w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	entry, ok := r.entries[chainId]
+	r.mu.RUnlock()
+
+	if !ok {
+		r.serveNotFound(w, req)
+		return
+	}
+	r.serve(entry.handler, w, req)
+}
+
+// serveIndex lists the chains on the root path, any other unknown path gets the list with 404
+func (r *Registry) serveIndex(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		r.serveNotFound(w, req)
+		return
+	}
+	r.writeIndex(w, http.StatusOK)
+}
+
+func (r *Registry) serveNotFound(w http.ResponseWriter, req *http.Request) {
+	r.writeIndex(w, http.StatusNotFound)
+}
+
+func (r *Registry) writeIndex(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(struct {
+		Chains []ChainEntry `json:"chains"`
+	}{r.Chains()}); err != nil {
+		log.Debugf("Index of %s encode failed: %v", r.name, err)
+	}
+}
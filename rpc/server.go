@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/log"
@@ -13,16 +14,27 @@ import (
 )
 
 var (
-	httpListener       net.Listener
-	httpMux            *http.ServeMux
-	httpHandlerMapping map[string]*rpc.Server
-
-	wsListener       net.Listener
-	wsMux            *http.ServeMux
-	wsOrigins        []string
-	wsHandlerMapping map[string]*rpc.Server
+	httpListener net.Listener
+	httpMux      *http.ServeMux
+	httpRegistry *Registry
+
+	wsListener net.Listener
+	wsMux      *http.ServeMux
+	wsOrigins  []string
+	wsRegistry *Registry
+
+	// Reports the status of the hosted chains on the /health path
+	healthReporter HealthReporter
 )
 
+// HealthReporter returns the status to be served as json, and whether the node is healthy
+type HealthReporter func() (interface{}, bool)
+
+// SetHealthReporter sets the reporter of the /health path, it must be set before StartRPC
+func SetHealthReporter(reporter HealthReporter) {
+	healthReporter = reporter
+}
+
 func StartRPC(ctx *cli.Context) error {
 
 	// Use Default Config
@@ -54,10 +66,8 @@ func StopRPC() {
 		httpListener = nil
 		log.Info("HTTP endpoint closed", "url", fmt.Sprintf("http://%s", httpAddr))
 	}
-	if httpMux != nil {
-		for _, httpHandler := range httpHandlerMapping {
-			httpHandler.Stop()
-		}
+	if httpRegistry != nil {
+		httpRegistry.Stop()
 	}
 
 	// Stop WS Listener
@@ -67,10 +77,8 @@ func StopRPC() {
 		wsListener = nil
 		log.Info("WebSocket endpoint closed", "url", fmt.Sprintf("ws://%s", wsAddr))
 	}
-	if wsMux != nil {
-		for _, wsHandler := range wsHandlerMapping {
-			wsHandler.Stop()
-		}
+	if wsRegistry != nil {
+		wsRegistry.Stop()
 	}
 }
 
@@ -82,28 +90,49 @@ func IsWSRunning() bool {
 	return wsListener != nil && wsMux != nil
 }
 
-func HookupHTTP(chainId string, httpHandler *rpc.Server) error {
-	if httpMux != nil {
-		log.Infof("Hookup HTTP for (chainId, http Handler): (%v, %v)", chainId, httpHandler)
-		if httpHandler != nil {
-			httpMux.Handle("/"+chainId, httpHandler)
-			httpHandlerMapping[chainId] = httpHandler
-		}
+func HookupHTTP(chain ChainEntry, httpHandler *rpc.Server) error {
+	if httpRegistry != nil && httpHandler != nil {
+		httpRegistry.Register(chain, httpHandler)
 	}
 	return nil
 }
 
-func HookupWS(chainId string, wsHandler *rpc.Server) error {
-	if wsMux != nil {
-		log.Infof("Hookup WS for (chainId, ws Handler): (%v, %v)", chainId, wsHandler)
-		if wsHandler != nil {
-			wsMux.Handle("/"+chainId, wsHandler.WebsocketHandler(wsOrigins))
-			wsHandlerMapping[chainId] = wsHandler
-		}
+func HookupWS(chain ChainEntry, wsHandler *rpc.Server) error {
+	if wsRegistry != nil && wsHandler != nil {
+		wsRegistry.Register(chain, wsHandler)
 	}
 	return nil
 }
 
+// UnhookHTTP stops serving the chain on the HTTP endpoint, the path returns 404 until it is hooked up again
+func UnhookHTTP(chainId string) {
+	if httpRegistry != nil {
+		httpRegistry.Unregister(chainId)
+	}
+}
+
+// UnhookWS stops serving the chain on the WS endpoint, the path returns 404 until it is hooked up again
+func UnhookWS(chainId string) {
+	if wsRegistry != nil {
+		wsRegistry.Unregister(chainId)
+	}
+}
+
+// healthHandler serves the status as json, with 503 status code if the node is not healthy
+func healthHandler(reporter HealthReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, healthy := reporter()
+
+		w.Header().Set("Content-Type", "application/json")
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			log.Debugf("Health status encode failed: %v", err)
+		}
+	})
+}
+
 func startHTTP(endpoint string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
 	if endpoint == "" {
@@ -115,7 +144,13 @@ func startHTTP(endpoint string, cors []string, vhosts []string, timeouts rpc.HTT
 	if err != nil {
 		return err
 	}
-	httpHandlerMapping = make(map[string]*rpc.Server)
+	httpRegistry = NewRegistry("HTTP", httpMux, func(handler *rpc.Server, w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	})
+
+	if healthReporter != nil {
+		httpMux.Handle("/health", healthHandler(healthReporter))
+	}
 
 	log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
 	return nil
@@ -145,7 +180,9 @@ func startWS(endpoint string) error {
 	if err != nil {
 		return err
 	}
-	wsHandlerMapping = make(map[string]*rpc.Server)
+	wsRegistry = NewRegistry("WS", wsMux, func(handler *rpc.Server, w http.ResponseWriter, r *http.Request) {
+		handler.WebsocketHandler(wsOrigins).ServeHTTP(w, r)
+	})
 
 	log.Info("WebSocket endpoint opened", "url", fmt.Sprintf("ws://%s", wsListener.Addr()))
 	return nil